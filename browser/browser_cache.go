@@ -0,0 +1,43 @@
+package browser
+
+import "sync"
+
+// maxCachedBrowsers bounds how many distinct *Browser instances this
+// package remembers derived state for (parsed forms, CSRF tokens). Browser
+// has no field of its own to hang this state off of, so it's kept here
+// instead; capping the size keeps a crawler that creates many short-lived
+// Browsers from leaking them into this package forever. Oldest entries are
+// evicted first once the cap is reached.
+const maxCachedBrowsers = 256
+
+// browserCache is a small, bounded, insertion-ordered store keyed by
+// *Browser.
+type browserCache struct {
+	mu      sync.Mutex
+	entries map[*Browser]interface{}
+	order   []*Browser
+}
+
+func newBrowserCache() *browserCache {
+	return &browserCache{entries: map[*Browser]interface{}{}}
+}
+
+// getOrCreate returns the cached value for bow, creating it with create
+// when absent.
+func (c *browserCache) getOrCreate(bow *Browser, create func() interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.entries[bow]; ok {
+		return v
+	}
+	v := create()
+	c.entries[bow] = v
+	c.order = append(c.order, bow)
+	if len(c.order) > maxCachedBrowsers {
+		var oldest *Browser
+		oldest, c.order = c.order[0], c.order[1:]
+		delete(c.entries, oldest)
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package browser
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/haruyama/surf/errors"
+)
+
+// formsCache holds the most recently parsed form list per Browser, so
+// repeated calls to Forms (directly or via the locator helpers below) don't
+// re-parse the whole page on every call.
+var formsCache = newBrowserCache()
+
+// formsCacheEntry is invalidated by comparing bodyHash against a hash of the
+// Browser's current page body. A plain URL comparison isn't enough: a form
+// that posts to itself leaves the URL unchanged even though the body (and
+// the forms on it) did change, so the cache has to key off content instead.
+type formsCacheEntry struct {
+	mu       sync.Mutex
+	bodyHash uint64
+	forms    []*Form
+}
+
+// hashBody returns a cheap fingerprint of body, used to tell whether the
+// page has changed since the last Forms call without keeping the whole
+// previous body around just for comparison.
+func hashBody(body string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(body))
+	return h.Sum64()
+}
+
+// Forms returns every <form> on the current page. The document is parsed
+// once per page load, via bow.Dom; later calls, including through
+// FormByAction, FormByIndex, and FormContaining, reuse that parse and the
+// cached *Form slice instead of re-querying the DOM.
+func (bow *Browser) Forms() []*Form {
+	entry := formsCache.getOrCreate(bow, func() interface{} {
+		return &formsCacheEntry{}
+	}).(*formsCacheEntry)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	hash := hashBody(bow.Body())
+	if entry.bodyHash == hash && entry.forms != nil {
+		return entry.forms
+	}
+
+	var forms []*Form
+	bow.Dom().Find("form").Each(func(_ int, s *goquery.Selection) {
+		forms = append(forms, NewForm(bow, s))
+	})
+
+	entry.bodyHash = hash
+	entry.forms = forms
+	return forms
+}
+
+// FormByAction returns the first form on the page whose action URL contains
+// pattern.
+func (bow *Browser) FormByAction(pattern string) (*Form, error) {
+	for _, f := range bow.Forms() {
+		if strings.Contains(f.Action(), pattern) {
+			return f, nil
+		}
+	}
+	return nil, errors.NewElementNotFound(
+		"No form found with an action matching '%s'.", pattern)
+}
+
+// FormByIndex returns the form at index i, in document order.
+func (bow *Browser) FormByIndex(i int) (*Form, error) {
+	forms := bow.Forms()
+	if i < 0 || i >= len(forms) {
+		return nil, errors.NewElementNotFound(
+			"No form found at index %d.", i)
+	}
+	return forms[i], nil
+}
+
+// FormContaining returns the first form on the page that defines a field
+// with the given name, whether an input, select, textarea, or file field.
+func (bow *Browser) FormContaining(fieldName string) (*Form, error) {
+	for _, f := range bow.Forms() {
+		if f.definedFields[fieldName] || f.fileFields[fieldName] {
+			return f, nil
+		}
+	}
+	return nil, errors.NewElementNotFound(
+		"No form found containing a field named '%s'.", fieldName)
+}
@@ -0,0 +1,229 @@
+package browser
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// defaultCSRFTokenNames are the hidden-input names most frameworks use for
+// CSRF tokens.
+var defaultCSRFTokenNames = []string{
+	"_csrf", "csrf_token", "authenticity_token", "__RequestVerificationToken",
+}
+
+// CSRFExtractor returns a CSRF (name, value) pair found in a document, for
+// sites whose token doesn't fit the common hidden-input/meta-tag patterns.
+// ok is false when no token was found.
+type CSRFExtractor func(doc *goquery.Document) (name, value string, ok bool)
+
+// CSRFInjectionMode controls how a remembered CSRF token is attached to a
+// later same-origin submission.
+type CSRFInjectionMode string
+
+const (
+	// CSRFInjectField re-submits the token as a hidden form field. This is
+	// the default.
+	CSRFInjectField CSRFInjectionMode = "field"
+	// CSRFInjectHeader sends the token as an X-CSRF-Token request header
+	// instead of a form field.
+	CSRFInjectHeader CSRFInjectionMode = "header"
+)
+
+// csrfState is the remembered CSRF configuration and last-seen token for a
+// single Browser. It's kept in csrfCache rather than as a Browser field so
+// Form, which only knows Browsable, can look it up without a type switch at
+// every call site; csrfCache's size cap keeps that from pinning Browsers in
+// memory forever.
+type csrfState struct {
+	mu         sync.Mutex
+	names      []string
+	extractors []CSRFExtractor
+	mode       CSRFInjectionMode
+	header     string
+	origin     string
+	name       string
+	value      string
+}
+
+var csrfCache = newBrowserCache()
+
+func newCSRFState() *csrfState {
+	return &csrfState{
+		names:  defaultCSRFTokenNames,
+		mode:   CSRFInjectField,
+		header: "X-CSRF-Token",
+	}
+}
+
+// csrfStateFor returns the csrfState for bow, creating one on first use.
+// Browsable implementations other than *Browser get a fresh, unremembered
+// state every call, so they degrade to "no CSRF support" rather than panic.
+func csrfStateFor(bow Browsable) *csrfState {
+	b, ok := bow.(*Browser)
+	if !ok {
+		return newCSRFState()
+	}
+	return csrfCache.getOrCreate(b, func() interface{} {
+		return newCSRFState()
+	}).(*csrfState)
+}
+
+// SetCSRFTokenNames overrides the hidden-input names NewForm looks for when
+// auto-detecting a CSRF token, replacing the default list of common
+// framework token names (_csrf, authenticity_token, etc). The meta-tag
+// lookup always uses <meta name="csrf-token"> and is not affected.
+func (bow *Browser) SetCSRFTokenNames(names []string) {
+	s := csrfStateFor(bow)
+	s.mu.Lock()
+	s.names = names
+	s.mu.Unlock()
+}
+
+// SetCSRFInjectionMode controls whether a remembered CSRF token is
+// re-submitted as a hidden field (the default) or an X-CSRF-Token header.
+func (bow *Browser) SetCSRFInjectionMode(mode CSRFInjectionMode) {
+	s := csrfStateFor(bow)
+	s.mu.Lock()
+	s.mode = mode
+	s.mu.Unlock()
+}
+
+// AddCSRFExtractor registers a custom CSRF token extractor, consulted when
+// the known hidden-input and meta-tag names don't find a token.
+func (bow *Browser) AddCSRFExtractor(extractor CSRFExtractor) {
+	s := csrfStateFor(bow)
+	s.mu.Lock()
+	s.extractors = append(s.extractors, extractor)
+	s.mu.Unlock()
+}
+
+// detectCSRFToken looks for a CSRF token among the form's hidden inputs, a
+// <meta name="csrf-token"> tag on the page, or a registered CSRFExtractor,
+// remembering whatever is found for injection into later submissions to the
+// same origin.
+func detectCSRFToken(bow Browsable, sel *goquery.Selection) {
+	s := csrfStateFor(bow)
+	s.mu.Lock()
+	names := s.names
+	extractors := s.extractors
+	s.mu.Unlock()
+
+	var name, value string
+	found := false
+
+	sel.Find(`input[type="hidden"]`).EachWithBreak(func(_ int, in *goquery.Selection) bool {
+		n, ok := in.Attr("name")
+		if !ok || !containsFold(names, n) {
+			return true
+		}
+		name, value = n, in.AttrOr("value", "")
+		found = true
+		return false
+	})
+
+	root := sel.Closest("html")
+	if !found && root.Length() > 0 {
+		doc := goquery.NewDocumentFromNode(root.Get(0))
+		if meta := doc.Find(`meta[name="csrf-token"]`).First(); meta.Length() > 0 {
+			if v, ok := meta.Attr("content"); ok {
+				name, value, found = "csrf-token", v, true
+			}
+		}
+		if !found {
+			for _, extractor := range extractors {
+				if n, v, ok := extractor(doc); ok {
+					name, value, found = n, v, true
+					break
+				}
+			}
+		}
+	}
+
+	if !found {
+		return
+	}
+	s.mu.Lock()
+	s.origin = bow.Url().String()
+	s.name = name
+	s.value = value
+	s.mu.Unlock()
+}
+
+// injectCSRFToken adds the last-seen CSRF token to a submission bound for
+// the same origin it was discovered on, when the form doesn't already
+// define that field. It returns a cleanup func that must be called once the
+// request has been sent, undoing whatever header it set so the token
+// doesn't linger on later, possibly cross-origin, requests.
+func injectCSRFToken(bow Browsable, target *url.URL, values url.Values) func() {
+	noop := func() {}
+
+	s := csrfStateFor(bow)
+	s.mu.Lock()
+	name, value, origin, mode, header := s.name, s.value, s.origin, s.mode, s.header
+	s.mu.Unlock()
+
+	if name == "" || origin == "" || !sameOrigin(origin, target.String()) {
+		return noop
+	}
+	if len(values[name]) > 0 {
+		return noop
+	}
+	if mode == CSRFInjectHeader {
+		return setRequestHeader(bow, header, value)
+	}
+	values.Set(name, value)
+	return noop
+}
+
+// setRequestHeader sets header to value for the request about to be sent
+// only, returning a cleanup func that restores whatever the header held
+// before (or removes it, if it was unset). Browsable's AddRequestHeader adds
+// to the Browser's persistent header jar, which every later request reuses,
+// so a *Browser is handled by reaching into its header jar directly instead;
+// other Browsable implementations fall back to AddRequestHeader uncleaned,
+// since there's no scoped-header hook to undo it with.
+func setRequestHeader(bow Browsable, header, value string) func() {
+	b, ok := bow.(*Browser)
+	if !ok {
+		bow.AddRequestHeader(header, value)
+		return func() {}
+	}
+	if b.headers == nil {
+		b.headers = make(http.Header)
+	}
+	key := http.CanonicalHeaderKey(header)
+	prev, had := b.headers[key]
+	b.headers.Set(header, value)
+	return func() {
+		if had {
+			b.headers[key] = prev
+		} else {
+			b.headers.Del(header)
+		}
+	}
+}
+
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Scheme == ub.Scheme && ua.Host == ub.Host
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,14 @@
 package browser
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
 	"net/url"
+	"path/filepath"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -18,35 +25,61 @@ type Submittable interface {
 	DeleteField(name string) error
 	InputSlice(name string, values []string) error
 	CheckBox(name string, values []string) error
+	File(name, filename string, r io.Reader) error
 	Click(button string) error
 	Submit() error
 	Dom() *goquery.Selection
 }
 
+// formFile holds the contents of a file attached to a form field via File,
+// to be encoded when the form is submitted as multipart/form-data.
+type formFile struct {
+	filename string
+	reader   io.Reader
+}
+
 // Form is the default form element.
 type Form struct {
-	bow           Browsable
-	selection     *goquery.Selection
-	method        string
-	action        string
-	definedFields map[string]bool
-	fields        url.Values
-	buttons       url.Values
+	bow            Browsable
+	selection      *goquery.Selection
+	method         string
+	action         string
+	definedFields  map[string]bool
+	fileFields     map[string]bool
+	imageFields    map[string]bool
+	fields         url.Values
+	files          map[string]*formFile
+	buttons        url.Values
+	constraints    map[string]*fieldConstraint
+	selectOptions  map[string][]string
+	multiSelects   map[string]bool
+	checkboxValues map[string]string
+	validationMode ValidationMode
+	fieldErrors    []FieldError
 }
 
 // NewForm creates and returns a *Form type.
 func NewForm(bow Browsable, s *goquery.Selection) *Form {
-	definedFields, fields, buttons := serializeForm(s)
+	data := serializeForm(s)
 	method, action := formAttributes(bow, s)
+	detectCSRFToken(bow, s)
 
 	return &Form{
-		bow:           bow,
-		selection:     s,
-		method:        method,
-		action:        action,
-		definedFields: definedFields,
-		fields:        fields,
-		buttons:       buttons,
+		bow:            bow,
+		selection:      s,
+		method:         method,
+		action:         action,
+		definedFields:  data.definedFields,
+		fileFields:     data.fileFields,
+		imageFields:    data.imageFields,
+		fields:         data.fields,
+		files:          make(map[string]*formFile),
+		buttons:        data.buttons,
+		constraints:    data.constraints,
+		selectOptions:  data.selectOptions,
+		multiSelects:   data.multiSelects,
+		checkboxValues: data.checkboxValues,
+		validationMode: ValidationOff,
 	}
 }
 
@@ -113,6 +146,46 @@ func (f *Form) CheckBox(name string, values []string) error {
 	return f.InputSlice(name, values)
 }
 
+// Select sets the selected option(s) of a <select> field, validating that
+// each value names an existing <option> and that multiple values are only
+// given to a <select multiple>.
+func (f *Form) Select(name string, values ...string) error {
+	opts, ok := f.selectOptions[name]
+	if !ok {
+		return errors.NewElementNotFound(
+			"No select found with name '%s'.", name)
+	}
+	if !f.multiSelects[name] && len(values) > 1 {
+		return errors.NewInvalidFormValue(
+			"Select '%s' does not accept multiple values.", name)
+	}
+	for _, v := range values {
+		found := false
+		for _, o := range opts {
+			if o == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.NewInvalidFormValue(
+				"Select '%s' does not have an option '%s'.", name, v)
+		}
+	}
+	return f.InputSlice(name, values)
+}
+
+// File attaches a file to the named file input field. The file is encoded
+// as part of the request when the form is submitted as multipart/form-data.
+func (f *Form) File(name, filename string, r io.Reader) error {
+	if f.fileFields[name] {
+		f.files[name] = &formFile{filename: filename, reader: r}
+		return nil
+	}
+	return errors.NewElementNotFound(
+		"No file input found with name '%s'.", name)
+}
+
 // Submit submits the form.
 // Clicks the first button in the form, or submits the form without using
 // any button when the form does not contain any buttons.
@@ -122,7 +195,7 @@ func (f *Form) Submit() error {
 			return f.Click(name)
 		}
 	}
-	return f.send("", "")
+	return f.submit("", "")
 }
 
 // Click submits the form by clicking the button with the given name.
@@ -131,7 +204,7 @@ func (f *Form) Click(button string) error {
 		return errors.NewInvalidFormValue(
 			"Form does not contain a button with the name '%s'.", button)
 	}
-	return f.send(button, f.buttons[button][0])
+	return f.submit(button, f.buttons[button][0])
 }
 
 // Dom returns the inner *goquery.Selection.
@@ -139,6 +212,17 @@ func (f *Form) Dom() *goquery.Selection {
 	return f.selection
 }
 
+// submit validates the form according to the current ValidationMode before
+// handing off to send.
+func (f *Form) submit(buttonName, buttonValue string) error {
+	if f.validationMode != ValidationOff {
+		if err := f.Validate(); err != nil && f.validationMode == ValidationStrict {
+			return err
+		}
+	}
+	return f.send(buttonName, buttonValue)
+}
+
 // send submits the form.
 func (f *Form) send(buttonName, buttonValue string) error {
 	method, ok := f.selection.Attr("method")
@@ -163,87 +247,213 @@ func (f *Form) send(buttonName, buttonValue string) error {
 		values[name] = vals
 	}
 	if buttonName != "" {
-		values.Set(buttonName, buttonValue)
+		if f.imageFields[buttonName] {
+			values.Set(buttonName+".x", "0")
+			values.Set(buttonName+".y", "0")
+		} else {
+			values.Set(buttonName, buttonValue)
+		}
 	}
+	cleanup := injectCSRFToken(f.bow, aurl, values)
+	defer cleanup()
 
 	if strings.ToUpper(method) == "GET" {
 		return f.bow.OpenForm(aurl.String(), values)
 	}
 	enctype, _ := f.selection.Attr("enctype")
 	if enctype == "multipart/form-data" {
+		if len(f.files) > 0 {
+			return f.sendMultipartFiles(aurl.String(), values)
+		}
 		return f.bow.PostMultipart(aurl.String(), values)
 	}
 	return f.bow.PostForm(aurl.String(), values)
 }
 
-// Serialize converts the form fields into a url.Values type.
-// Returns two url.Value types. The first is the form field values, and the
-// second is the form button values.
-func serializeForm(sel *goquery.Selection) (map[string]bool, url.Values, url.Values) {
-	input := sel.Find("input,button")
-	definedFields := map[string]bool{}
-	fields := make(url.Values)
-	buttons := make(url.Values)
+// sendMultipartFiles posts the form as multipart/form-data, encoding the
+// regular field values alongside any files attached via File.
+func (f *Form) sendMultipartFiles(action string, values url.Values) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for name, vals := range values {
+		for _, v := range vals {
+			if err := w.WriteField(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	for name, file := range f.files {
+		ctype := mime.TypeByExtension(filepath.Ext(file.filename))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(
+			`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(name), escapeQuotes(file.filename)))
+		header.Set("Content-Type", ctype)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, file.reader); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.bow.Post(action, w.FormDataContentType(), &buf)
+}
+
+// quoteEscaper matches the unexported one mime/multipart uses in
+// CreateFormFile, so a field name or filename containing a quote or
+// backslash can't break out of the quoted Content-Disposition parameter.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// formData holds the parsed-out pieces of a <form> element, as produced by
+// serializeForm.
+type formData struct {
+	definedFields  map[string]bool
+	fileFields     map[string]bool
+	imageFields    map[string]bool
+	fields         url.Values
+	buttons        url.Values
+	constraints    map[string]*fieldConstraint
+	selectOptions  map[string][]string
+	multiSelects   map[string]bool
+	checkboxValues map[string]string
+}
+
+// Serialize converts the form fields into a *formData.
+func serializeForm(sel *goquery.Selection) *formData {
+	data := &formData{
+		definedFields:  map[string]bool{},
+		fileFields:     map[string]bool{},
+		imageFields:    map[string]bool{},
+		fields:         make(url.Values),
+		buttons:        make(url.Values),
+		constraints:    map[string]*fieldConstraint{},
+		selectOptions:  map[string][]string{},
+		multiSelects:   map[string]bool{},
+		checkboxValues: map[string]string{},
+	}
 
-	input.Each(func(_ int, s *goquery.Selection) {
+	sel.Find("input,button").Each(func(_ int, s *goquery.Selection) {
 		name, ok := s.Attr("name")
-		if ok {
-			typ, ok := s.Attr("type")
+		if !ok {
+			return
+		}
+		typ, hasType := s.Attr("type")
+
+		if goquery.NodeName(s) == "button" {
+			if hasType && typ != "submit" {
+				return
+			}
+			val, ok := s.Attr("value")
+			if !ok {
+				val = strings.TrimSpace(s.Text())
+			}
+			data.buttons.Add(name, val)
+			return
+		}
+		if !hasType {
+			typ = "text"
+		}
+
+		switch typ {
+		case "submit":
+			val, ok := s.Attr("value")
 			if ok {
-				if typ == "submit" {
-					val, ok := s.Attr("value")
-					if ok {
-						buttons.Add(name, val)
-					} else {
-						buttons.Add(name, "")
-					}
-				} else if typ == "radio" || typ == "checkbox" {
-					definedFields[name] = true
-					_, ok := s.Attr("checked")
-					if ok {
-						val, ok := s.Attr("value")
-						if ok {
-							fields.Add(name, val)
-						}
-					}
-				} else {
-					definedFields[name] = true
-					val, ok := s.Attr("value")
-					if ok {
-						fields.Add(name, val)
-					}
+				data.buttons.Add(name, val)
+			} else {
+				data.buttons.Add(name, "")
+			}
+		case "image":
+			data.imageFields[name] = true
+			data.buttons.Add(name, "")
+		case "radio", "checkbox":
+			data.definedFields[name] = true
+			val, hasVal := s.Attr("value")
+			if typ == "checkbox" {
+				if !hasVal {
+					val = "on"
 				}
+				data.checkboxValues[name] = val
+			}
+			if _, ok := s.Attr("checked"); ok && (hasVal || typ == "checkbox") {
+				data.fields.Add(name, val)
+			}
+			if c := parseFieldConstraint(s, typ); c != nil {
+				data.constraints[name] = c
+			}
+		case "file":
+			data.fileFields[name] = true
+		default:
+			data.definedFields[name] = true
+			if val, ok := s.Attr("value"); ok {
+				data.fields.Add(name, val)
+			}
+			if c := parseFieldConstraint(s, typ); c != nil {
+				data.constraints[name] = c
 			}
 		}
 	})
 
-	selec := sel.Find("select")
-
-	selec.Each(func(_ int, s *goquery.Selection) {
+	sel.Find("select").Each(func(_ int, s *goquery.Selection) {
 		name, ok := s.Attr("name")
 		if !ok {
 			return
 		}
-		definedFields[name] = true
-		s.Find("option[selected]").Each(func(_ int, so *goquery.Selection) {
-			val, ok := so.Attr("value")
-			if ok {
-				fields.Add(name, val)
+		data.definedFields[name] = true
+		_, multiple := s.Attr("multiple")
+		data.multiSelects[name] = multiple
+
+		var opts, selected []string
+		s.Find("option").Each(func(_ int, o *goquery.Selection) {
+			val, ok := o.Attr("value")
+			if !ok {
+				val = strings.TrimSpace(o.Text())
+			}
+			opts = append(opts, val)
+			if _, ok := o.Attr("selected"); ok {
+				selected = append(selected, val)
 			}
 		})
+		data.selectOptions[name] = opts
+
+		if len(selected) == 0 && !multiple && len(opts) > 0 {
+			selected = opts[:1]
+		}
+		for _, val := range selected {
+			data.fields.Add(name, val)
+		}
+		// Only "required" applies to <select>; minlength/pattern/etc. are
+		// input-only constraints that parseFieldConstraint would otherwise
+		// pick up if present by mistake.
+		if _, ok := s.Attr("required"); ok {
+			data.constraints[name] = &fieldConstraint{required: true}
+		}
 	})
 
-	textarea := sel.Find("textarea")
-	textarea.Each(func(_ int, s *goquery.Selection) {
+	sel.Find("textarea").Each(func(_ int, s *goquery.Selection) {
 		name, ok := s.Attr("name")
 		if !ok {
 			return
 		}
-		definedFields[name] = true
-		fields.Add(name, s.Text())
+		data.definedFields[name] = true
+		data.fields.Add(name, s.Text())
+		if c := parseFieldConstraint(s, ""); c != nil {
+			data.constraints[name] = c
+		}
 	})
 
-	return definedFields, fields, buttons
+	return data
 }
 
 func formAttributes(bow Browsable, s *goquery.Selection) (string, string) {
@@ -0,0 +1,218 @@
+package browser
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haruyama/surf/errors"
+)
+
+// timeType is used to detect time.Time fields without importing reflect's
+// type-identity dance into every call site.
+var timeType = reflect.TypeOf(time.Time{})
+
+// parseFormTag splits a `form:"name,default=value"` tag into its field name
+// and optional default.
+func parseFormTag(tag string) (name, def string) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, p := range parts[1:] {
+		if strings.HasPrefix(p, "default=") {
+			def = strings.TrimPrefix(p, "default=")
+		}
+	}
+	return name, def
+}
+
+// Bind reads the current form field values into dst, a pointer to a struct
+// whose fields carry `form:"name"` tags. A `form:"name,default=value"` tag
+// supplies a default used when the field is empty or missing. []string
+// fields receive every value of a multi-valued field (eg checkboxes or a
+// <select multiple>), and time.Time fields are parsed with the layout named
+// by a `time_format` tag, defaulting to time.RFC3339.
+func (f *Form) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.NewInvalidFormValue("Bind requires a pointer to a struct.")
+	}
+	elem := v.Elem()
+	t := elem.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("form")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name, def := parseFormTag(tag)
+		fv := elem.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String {
+			vals, ok := f.fields[name]
+			if !ok || len(vals) == 0 {
+				if def == "" {
+					continue
+				}
+				vals = strings.Split(def, ",")
+			}
+			fv.Set(reflect.ValueOf(vals))
+			continue
+		}
+
+		value, ok := f.Field(name)
+		if !ok || value == "" {
+			if def == "" {
+				continue
+			}
+			value = def
+		}
+		if err := bindFieldValue(fv, sf, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindFieldValue assigns the string form value to a single struct field,
+// converting it according to the field's Go type.
+func bindFieldValue(fv reflect.Value, sf reflect.StructField, value string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			b = value != ""
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Struct:
+		if fv.Type() != timeType {
+			return errors.NewInvalidFormValue(
+				"Bind does not support field type %s for '%s'.", fv.Type(), sf.Name)
+		}
+		layout := sf.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+	default:
+		return errors.NewInvalidFormValue(
+			"Bind does not support field type %s for '%s'.", fv.Kind(), sf.Name)
+	}
+	return nil
+}
+
+// Fill sets form field values from src, a struct or pointer to a struct
+// whose fields carry `form:"name"` tags, the mirror image of Bind. Bool
+// fields set a checkbox-style field to its value attribute ("on" when it
+// has none) or delete it when false,
+// []string fields go through InputSlice, and time.Time fields are formatted
+// with the layout named by a `time_format` tag, defaulting to time.RFC3339.
+func (f *Form) Fill(src interface{}) error {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return errors.NewInvalidFormValue("Fill requires a struct or pointer to a struct.")
+	}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("form")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name, _ := parseFormTag(tag)
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String {
+			vals := make([]string, fv.Len())
+			for j := range vals {
+				vals[j] = fv.Index(j).String()
+			}
+			if err := f.InputSlice(name, vals); err != nil {
+				return err
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Bool {
+			if fv.Bool() {
+				checkedValue := f.checkboxValues[name]
+				if checkedValue == "" {
+					checkedValue = "on"
+				}
+				if err := f.Input(name, checkedValue); err != nil {
+					return err
+				}
+			} else if err := f.DeleteField(name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := formatFieldValue(fv, sf)
+		if err != nil {
+			return err
+		}
+		if err := f.Input(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatFieldValue converts a single struct field into the string form used
+// by Input, the mirror image of bindFieldValue.
+func formatFieldValue(fv reflect.Value, sf reflect.StructField) (string, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	case reflect.Struct:
+		if fv.Type() == timeType {
+			layout := sf.Tag.Get("time_format")
+			if layout == "" {
+				layout = time.RFC3339
+			}
+			return fv.Interface().(time.Time).Format(layout), nil
+		}
+	}
+	return "", errors.NewInvalidFormValue(
+		"Fill does not support field type %s for '%s'.", fv.Type(), sf.Name)
+}
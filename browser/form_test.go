@@ -11,6 +11,633 @@ import (
 	"github.com/headzoo/ut"
 )
 
+func TestBrowserFormFile(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, htmlFormFile)
+		} else {
+			r.ParseMultipartForm(1 << 20)
+			file, header, err := r.FormFile("upload")
+			if err != nil {
+				fmt.Fprintf(w, "error=%s", err)
+				return
+			}
+			defer file.Close()
+			buf := make([]byte, header.Size)
+			file.Read(buf)
+			fmt.Fprintf(w, "name=%s&filename=%s&contents=%s",
+				r.FormValue("name"), header.Filename, string(buf))
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	err = f.Input("name", "avatar")
+	ut.AssertNil(err)
+	err = f.File("upload", "avatar.txt", strings.NewReader("hello world"))
+	ut.AssertNil(err)
+	err = f.File("missing", "avatar.txt", strings.NewReader("hello world"))
+	ut.AssertNotNil(err)
+
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("name=avatar", bow.Body())
+	ut.AssertContains("filename=avatar.txt", bow.Body())
+	ut.AssertContains("contents=hello world", bow.Body())
+}
+
+func TestBrowserFormValidate(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, htmlFormValidate)
+		} else {
+			r.ParseForm()
+			fmt.Fprint(w, r.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	err = f.Validate()
+	ut.AssertNotNil(err)
+	verr, ok := err.(*ValidationError)
+	ut.AssertTrue(ok)
+	ut.AssertTrue(len(verr.Fields) > 0)
+
+	err = f.Input("email", "not-an-email")
+	ut.AssertNil(err)
+	err = f.Validate()
+	ut.AssertNotNil(err)
+
+	err = f.Input("email", "user@example.com")
+	ut.AssertNil(err)
+	err = f.Validate()
+	ut.AssertNotNil(err)
+	verr, ok = err.(*ValidationError)
+	ut.AssertTrue(ok)
+	ut.AssertFalse(fieldErrorsContain(verr.Fields, "email"))
+	ut.AssertTrue(fieldErrorsContain(verr.Fields, "name"))
+
+	f.(*Form).SetValidationMode(ValidationStrict)
+	err = f.Submit()
+	ut.AssertNotNil(err)
+
+	err = f.Input("name", "bob")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("name=bob", bow.Body())
+}
+
+var htmlFormValidate = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="text" name="name" value="" required minlength="2" />
+			<input type="email" name="email" value="" />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+func TestBrowserFormValidatePatternAndCheckbox(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, htmlFormValidatePatternAndCheckbox)
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	err = f.Input("zip", "12345abc")
+	ut.AssertNil(err)
+	err = f.Validate()
+	ut.AssertNotNil(err)
+	verr, ok := err.(*ValidationError)
+	ut.AssertTrue(ok)
+	ut.AssertTrue(fieldErrorsContain(verr.Fields, "zip"))
+	ut.AssertTrue(fieldErrorsContain(verr.Fields, "agree"))
+
+	err = f.Input("zip", "12345")
+	ut.AssertNil(err)
+	err = f.CheckBox("agree", []string{"yes"})
+	ut.AssertNil(err)
+	err = f.Validate()
+	ut.AssertNil(err)
+}
+
+func TestBrowserFormValidatePrecheckedValuelessCheckbox(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, htmlFormPrecheckedValuelessCheckbox)
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	err = f.Validate()
+	ut.AssertNil(err)
+}
+
+var htmlFormPrecheckedValuelessCheckbox = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="checkbox" name="agree" required checked />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+func fieldErrorsContain(fields []FieldError, name string) bool {
+	for _, fe := range fields {
+		if fe.Field == name {
+			return true
+		}
+	}
+	return false
+}
+
+var htmlFormValidatePatternAndCheckbox = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="text" name="zip" value="" pattern="\d{5}" />
+			<input type="checkbox" name="agree" value="yes" required />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+func TestBrowserFormSelectAndButtons(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, htmlFormSelect)
+		} else {
+			r.ParseForm()
+			fmt.Fprint(w, r.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	val, ok := f.Field("country")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("NY", val)
+
+	err = f.(*Form).Select("colors", "red", "blue")
+	ut.AssertNil(err)
+	err = f.(*Form).Select("colors", "purple")
+	ut.AssertNotNil(err)
+	err = f.(*Form).Select("country", "NY", "Tokyo")
+	ut.AssertNotNil(err)
+
+	err = f.Click("go")
+	ut.AssertNil(err)
+	ut.AssertContains("colors=red", bow.Body())
+	ut.AssertContains("colors=blue", bow.Body())
+	ut.AssertContains("country=NY", bow.Body())
+	ut.AssertContains("go=Go", bow.Body())
+}
+
+var htmlFormSelect = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<select name="country">
+				<option value="NY">New York</option>
+				<option value="Tokyo">Tokyo</option>
+			</select>
+			<select name="colors" multiple>
+				<option value="red">Red</option>
+				<option value="blue" selected>Blue</option>
+				<option value="green">Green</option>
+			</select>
+			<button name="go" value="Go">Go</button>
+		</form>
+	</body>
+</html>
+`
+
+func TestBrowserFormBindFill(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, htmlFormBind)
+		} else {
+			r.ParseForm()
+			fmt.Fprint(w, r.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	type profile struct {
+		Name      string   `form:"name"`
+		Age       int      `form:"age,default=18"`
+		Subscribe bool     `form:"subscribe"`
+		Hobbies   []string `form:"hobbies"`
+	}
+
+	var p profile
+	err = f.(*Form).Bind(&p)
+	ut.AssertNil(err)
+	ut.AssertEquals("bob", p.Name)
+	ut.AssertEquals(18, p.Age)
+	ut.AssertFalse(p.Subscribe)
+	ut.AssertEquals(2, len(p.Hobbies))
+
+	p.Name = "alice"
+	p.Age = 30
+	p.Subscribe = true
+	err = f.(*Form).Fill(&p)
+	ut.AssertNil(err)
+
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("name=alice", bow.Body())
+	ut.AssertContains("age=30", bow.Body())
+	ut.AssertContains("subscribe=yes", bow.Body())
+}
+
+var htmlFormBind = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="text" name="name" value="bob" />
+			<input type="text" name="age" value="" />
+			<input type="checkbox" name="subscribe" value="yes" />
+			<input type="checkbox" name="hobbies" value="reading" checked />
+			<input type="checkbox" name="hobbies" value="hiking" checked />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+func TestBrowserFormBindPrecheckedValuelessCheckbox(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, htmlFormPrecheckedValuelessCheckbox)
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	type agreement struct {
+		Agree bool `form:"agree"`
+	}
+
+	var a agreement
+	err = f.(*Form).Bind(&a)
+	ut.AssertNil(err)
+	ut.AssertTrue(a.Agree)
+}
+
+func TestBrowserForms(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, htmlMultipleForms)
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	forms := bow.Forms()
+	ut.AssertEquals(2, len(forms))
+
+	f, err := bow.FormByIndex(1)
+	ut.AssertNil(err)
+	ut.AssertTrue(strings.Contains(f.Action(), "/search"))
+
+	f, err = bow.FormByAction("/login")
+	ut.AssertNil(err)
+	_, ok := f.Field("username")
+	ut.AssertTrue(ok)
+
+	f, err = bow.FormContaining("query")
+	ut.AssertNil(err)
+	ut.AssertTrue(strings.Contains(f.Action(), "/search"))
+
+	_, err = bow.FormContaining("does-not-exist")
+	ut.AssertNotNil(err)
+}
+
+func TestBrowserFormsSelfPostingInvalidation(t *testing.T) {
+	ut.Run(t)
+	step := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			step++
+		}
+		if step == 0 {
+			fmt.Fprint(w, htmlSelfPostingStep1)
+		} else {
+			fmt.Fprint(w, htmlSelfPostingStep2)
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	forms := bow.Forms()
+	ut.AssertEquals(1, len(forms))
+	_, ok := forms[0].Field("step1field")
+	ut.AssertTrue(ok)
+
+	err = forms[0].Submit()
+	ut.AssertNil(err)
+
+	forms = bow.Forms()
+	ut.AssertEquals(1, len(forms))
+	_, ok = forms[0].Field("step2field")
+	ut.AssertTrue(ok)
+}
+
+var htmlSelfPostingStep1 = `<!doctype html>
+<html>
+	<head>
+		<title>Wizard Step 1</title>
+	</head>
+	<body>
+		<form method="post" action="" name="wizard">
+			<input type="text" name="step1field" value="a" />
+			<input type="submit" name="submit1" value="next" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlSelfPostingStep2 = `<!doctype html>
+<html>
+	<head>
+		<title>Wizard Step 2</title>
+	</head>
+	<body>
+		<form method="post" action="" name="wizard">
+			<input type="text" name="step2field" value="b" />
+			<input type="submit" name="submit2" value="finish" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlMultipleForms = `<!doctype html>
+<html>
+	<head>
+		<title>Multiple Forms</title>
+	</head>
+	<body>
+		<form method="post" action="/login" name="login">
+			<input type="text" name="username" value="" />
+		</form>
+		<form method="get" action="/search" name="search">
+			<input type="text" name="query" value="" />
+		</form>
+	</body>
+</html>
+`
+
+func TestBrowserFormCSRFReinjection(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if r.URL.Path == "/page2" {
+				fmt.Fprint(w, htmlCSRFPage2)
+			} else {
+				fmt.Fprint(w, htmlCSRFPage1)
+			}
+		} else {
+			r.ParseForm()
+			fmt.Fprint(w, r.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	f1, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f1.Input("a", "1")
+	ut.AssertNil(err)
+	err = f1.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("_csrf=tok123", bow.Body())
+
+	err = bow.Open(ts.URL + "/page2")
+	ut.AssertNil(err)
+
+	f2, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f2.Input("b", "2")
+	ut.AssertNil(err)
+	err = f2.Submit()
+	ut.AssertNil(err)
+	ut.AssertContains("_csrf=tok123", bow.Body())
+	ut.AssertContains("b=2", bow.Body())
+}
+
+func TestBrowserFormCSRFHeaderInjection(t *testing.T) {
+	ut.Run(t)
+	var headerCounts []int
+	var lastHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if r.URL.Path == "/page2" {
+				fmt.Fprint(w, htmlCSRFPage2)
+			} else {
+				fmt.Fprint(w, htmlCSRFPage1)
+			}
+		} else {
+			headerCounts = append(headerCounts, len(r.Header["X-Csrf-Token"]))
+			lastHeader = r.Header.Get("X-Csrf-Token")
+			r.ParseForm()
+			fmt.Fprint(w, r.Form.Encode())
+		}
+	}))
+	defer ts.Close()
+
+	bow := &Browser{}
+	bow.headers = make(http.Header, 10)
+	bow.history = jar.NewMemoryHistory()
+	bow.SetCSRFInjectionMode(CSRFInjectHeader)
+
+	err := bow.Open(ts.URL)
+	ut.AssertNil(err)
+
+	// Page 1 carries the token; parsing its form remembers it.
+	_, err = bow.Form("[name='default']")
+	ut.AssertNil(err)
+
+	// Page 2's form lacks the token field, so the token goes in the header.
+	err = bow.Open(ts.URL + "/page2")
+	ut.AssertNil(err)
+	f, err := bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Input("b", "1")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("tok123", lastHeader)
+	ut.AssertEquals("", bow.headers.Get("X-CSRF-Token"))
+
+	err = bow.Open(ts.URL + "/page2")
+	ut.AssertNil(err)
+	f, err = bow.Form("[name='default']")
+	ut.AssertNil(err)
+	err = f.Input("b", "2")
+	ut.AssertNil(err)
+	err = f.Submit()
+	ut.AssertNil(err)
+	ut.AssertEquals("tok123", lastHeader)
+	ut.AssertEquals("", bow.headers.Get("X-CSRF-Token"))
+
+	ut.AssertEquals(2, len(headerCounts))
+	for _, c := range headerCounts {
+		ut.AssertEquals(1, c)
+	}
+}
+
+var htmlCSRFPage1 = `<!doctype html>
+<html>
+	<head>
+		<title>Page 1</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="hidden" name="_csrf" value="tok123" />
+			<input type="text" name="a" value="" />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlCSRFPage2 = `<!doctype html>
+<html>
+	<head>
+		<title>Page 2</title>
+	</head>
+	<body>
+		<form method="post" action="/" name="default">
+			<input type="text" name="b" value="" />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
+var htmlFormFile = `<!doctype html>
+<html>
+	<head>
+		<title>Echo Form</title>
+	</head>
+	<body>
+		<form method="post" action="/" enctype="multipart/form-data" name="default">
+			<input type="text" name="name" value="" />
+			<input type="file" name="upload" />
+			<input type="submit" name="submit1" value="submitted1" />
+		</form>
+	</body>
+</html>
+`
+
 func TestBrowserForm(t *testing.T) {
 	ut.Run(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
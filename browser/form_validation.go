@@ -0,0 +1,194 @@
+package browser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ValidationMode controls whether Submit and Click enforce Validate before
+// sending the request.
+type ValidationMode string
+
+const (
+	// ValidationOff disables validation. This is the default, so existing
+	// callers are unaffected.
+	ValidationOff ValidationMode = "off"
+	// ValidationWarn runs Validate and records the errors, retrievable via
+	// Errors, but still submits the form.
+	ValidationWarn ValidationMode = "warn"
+	// ValidationStrict runs Validate and aborts Submit/Click with a
+	// *ValidationError when any field fails its constraints.
+	ValidationStrict ValidationMode = "strict"
+)
+
+// emailPattern is a pragmatic approximation of the HTML5 email constraint.
+var emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+
+// fieldConstraint holds the HTML5 validation attributes parsed from a single
+// input element.
+type fieldConstraint struct {
+	required  bool
+	hasMinLen bool
+	minLen    int
+	hasMaxLen bool
+	maxLen    int
+	hasMin    bool
+	min       float64
+	hasMax    bool
+	max       float64
+	pattern   *regexp.Regexp
+	inputType string
+}
+
+// parseFieldConstraint builds a fieldConstraint from an input element's
+// attributes, returning nil when the element carries no constraints worth
+// checking.
+func parseFieldConstraint(s *goquery.Selection, typ string) *fieldConstraint {
+	c := &fieldConstraint{}
+	found := false
+
+	if _, ok := s.Attr("required"); ok {
+		c.required = true
+		found = true
+	}
+	if v, ok := s.Attr("minlength"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.hasMinLen = true
+			c.minLen = n
+			found = true
+		}
+	}
+	if v, ok := s.Attr("maxlength"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.hasMaxLen = true
+			c.maxLen = n
+			found = true
+		}
+	}
+	if v, ok := s.Attr("min"); ok {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			c.hasMin = true
+			c.min = n
+			found = true
+		}
+	}
+	if v, ok := s.Attr("max"); ok {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			c.hasMax = true
+			c.max = n
+			found = true
+		}
+	}
+	if v, ok := s.Attr("pattern"); ok {
+		// HTML5's pattern attribute matches the entire value, not just a
+		// substring of it, so anchor the compiled pattern accordingly.
+		if re, err := regexp.Compile("^(?:" + v + ")$"); err == nil {
+			c.pattern = re
+			found = true
+		}
+	}
+	if typ == "email" || typ == "url" {
+		c.inputType = typ
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return c
+}
+
+// FieldError describes a single field that failed validation.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError aggregates the FieldErrors found by Validate.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, fe := range e.Fields {
+		msgs[i] = fmt.Sprintf("%s %s", fe.Field, fe.Message)
+	}
+	return "form validation failed: " + strings.Join(msgs, "; ")
+}
+
+// SetValidationMode controls whether Submit and Click enforce Validate
+// before issuing the HTTP request. Defaults to ValidationOff.
+func (f *Form) SetValidationMode(mode ValidationMode) {
+	f.validationMode = mode
+}
+
+// Errors returns the FieldErrors found by the most recent call to Validate.
+func (f *Form) Errors() []FieldError {
+	return f.fieldErrors
+}
+
+// Validate checks the current field values against the HTML5 constraint
+// attributes (required, minlength, maxlength, min, max, pattern, and the
+// email/url input types) parsed from the form. It returns a *ValidationError
+// aggregating every failing field, or nil when all fields are valid.
+func (f *Form) Validate() error {
+	var fieldErrs []FieldError
+
+	for name, c := range f.constraints {
+		value := f.fields.Get(name)
+		if c.required && strings.TrimSpace(value) == "" {
+			fieldErrs = append(fieldErrs, FieldError{name, "is required"})
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		if c.hasMinLen && utf8.RuneCountInString(value) < c.minLen {
+			fieldErrs = append(fieldErrs, FieldError{
+				name, fmt.Sprintf("must be at least %d characters", c.minLen)})
+		}
+		if c.hasMaxLen && utf8.RuneCountInString(value) > c.maxLen {
+			fieldErrs = append(fieldErrs, FieldError{
+				name, fmt.Sprintf("must be at most %d characters", c.maxLen)})
+		}
+		if c.hasMin || c.hasMax {
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				if c.hasMin && n < c.min {
+					fieldErrs = append(fieldErrs, FieldError{
+						name, fmt.Sprintf("must be at least %v", c.min)})
+				}
+				if c.hasMax && n > c.max {
+					fieldErrs = append(fieldErrs, FieldError{
+						name, fmt.Sprintf("must be at most %v", c.max)})
+				}
+			}
+		}
+		if c.pattern != nil && !c.pattern.MatchString(value) {
+			fieldErrs = append(fieldErrs, FieldError{name, "does not match the required pattern"})
+		}
+		switch c.inputType {
+		case "email":
+			if !emailPattern.MatchString(value) {
+				fieldErrs = append(fieldErrs, FieldError{name, "is not a valid email address"})
+			}
+		case "url":
+			if _, err := url.ParseRequestURI(value); err != nil {
+				fieldErrs = append(fieldErrs, FieldError{name, "is not a valid URL"})
+			}
+		}
+	}
+
+	f.fieldErrors = fieldErrs
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fieldErrs}
+}